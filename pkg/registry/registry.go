@@ -0,0 +1,12 @@
+// Package registry defines the interface background services implement so
+// the server can start and stop them uniformly, instead of each service
+// owning its own startup wiring.
+package registry
+
+import "context"
+
+// BackgroundService is a long-running process the server starts at boot and
+// keeps running until ctx is done.
+type BackgroundService interface {
+	Run(ctx context.Context) error
+}
@@ -0,0 +1,15 @@
+package server
+
+import (
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/gc"
+)
+
+// ProvideBackgroundServices collects every registry.BackgroundService the
+// server starts at boot. gc.Runner is the first registrant; its ticker
+// otherwise never runs and login-attempt rows are never swept.
+func ProvideBackgroundServices(gcRunner *gc.Runner) []registry.BackgroundService {
+	return []registry.BackgroundService{
+		gcRunner,
+	}
+}
@@ -0,0 +1,37 @@
+// Package server assembles Grafana's background services and runs them for
+// the lifetime of the process.
+package server
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/registry"
+)
+
+// Server owns every registry.BackgroundService the process starts at boot.
+type Server struct {
+	backgroundServices []registry.BackgroundService
+	log                log.Logger
+}
+
+func ProvideServer(backgroundServices []registry.BackgroundService) *Server {
+	return &Server{backgroundServices, log.New("server")}
+}
+
+// Run starts every background service and blocks until ctx is done or one
+// of them returns an error.
+func (s *Server) Run(ctx context.Context) error {
+	g, ctx := errgroup.WithContext(ctx)
+
+	for _, svc := range s.backgroundServices {
+		svc := svc
+		g.Go(func() error {
+			return svc.Run(ctx)
+		})
+	}
+
+	return g.Wait()
+}
@@ -0,0 +1,77 @@
+package authn
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+)
+
+// BruteForceGuard is the login-endpoint-facing entry point for
+// loginattempt.Service. It runs both the per-username and per-IP checks and
+// returns whichever ValidateResult blocked the login, so callers get one
+// typed answer telling them whether the block was user-scoped or IP-scoped
+// instead of calling Validate and ValidateIPAddress separately and
+// reconciling the two results themselves. It also writes the Retry-After
+// header and the audit log entry for a blocked result, so every login
+// surface reports lockouts the same way.
+type BruteForceGuard struct {
+	attempts loginattempt.Service
+	log      log.Logger
+}
+
+func ProvideBruteForceGuard(attempts loginattempt.Service) *BruteForceGuard {
+	return &BruteForceGuard{attempts, log.New("authn.bruteforce")}
+}
+
+// Check validates username and IPAddress against loginattempt's thresholds.
+// The returned ValidateResult is always populated, even when Allowed is
+// true, so callers can surface AttemptsRemaining either way. A blocked
+// result is audit logged before it's returned.
+func (g *BruteForceGuard) Check(ctx context.Context, username, IPAddress string) (loginattempt.ValidateResult, error) {
+	userResult, err := g.attempts.ValidatePair(ctx, username, IPAddress)
+	if err != nil {
+		return loginattempt.ValidateResult{}, err
+	}
+	if !userResult.Allowed {
+		g.audit(userResult, username, IPAddress)
+		return userResult, nil
+	}
+
+	ipResult, err := g.attempts.ValidateIPAddress(ctx, IPAddress)
+	if err != nil {
+		return loginattempt.ValidateResult{}, err
+	}
+	if !ipResult.Allowed {
+		g.audit(ipResult, username, IPAddress)
+		return ipResult, nil
+	}
+
+	return userResult, nil
+}
+
+// audit records why a login was refused, including which scope and tier
+// triggered it, so repeated lockouts show up in the log the same way other
+// authentication decisions do.
+func (g *BruteForceGuard) audit(result loginattempt.ValidateResult, username, IPAddress string) {
+	g.log.Warn("login blocked by brute-force protection",
+		"username", username,
+		"ip", IPAddress,
+		"reason", result.Reason.String(),
+		"tier", result.Tier,
+		"retryAfter", result.RetryAfter)
+}
+
+// WriteRetryAfter sets the Retry-After header, in whole seconds rounded up,
+// from a blocked ValidateResult. Callers should only call this when
+// result.Allowed is false.
+func WriteRetryAfter(w http.ResponseWriter, result loginattempt.ValidateResult) {
+	seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+	if seconds < 0 {
+		seconds = 0
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,83 @@
+// Package gc runs garbage collection for any service that has expired
+// artifacts to sweep (login attempts today; user/auth tokens, temp users and
+// short URLs are natural future registrants). Rather than each service
+// owning its own 10-minute ticker and lock, they implement GarbageCollector
+// and are injected into a single Runner that ticks once and calls each of
+// them under one serverlock.
+package gc
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+)
+
+// defaultInterval is how often the Runner wakes up to sweep registered
+// collectors.
+const defaultInterval = time.Minute * 10
+
+// Result reports, per kind of artifact removed (e.g. "login_attempt"), how
+// many rows a single GarbageCollector pass deleted. A collector that manages
+// more than one table can report on all of them in one Result.
+type Result map[string]int64
+
+// GarbageCollector is implemented by services that want their expired rows
+// swept on the shared Runner ticker instead of running their own goroutine.
+type GarbageCollector interface {
+	GarbageCollect(ctx context.Context, now time.Time) (Result, error)
+}
+
+// Runner ticks on a single interval and runs every registered
+// GarbageCollector under one serverlock.ServerLockService lease, so adding a
+// new collector doesn't cost the process another background goroutine.
+type Runner struct {
+	collectors []GarbageCollector
+	lock       *serverlock.ServerLockService
+	interval   time.Duration
+	logger     log.Logger
+}
+
+func ProvideRunner(lock *serverlock.ServerLockService, collectors []GarbageCollector) *Runner {
+	return &Runner{
+		collectors: collectors,
+		lock:       lock,
+		interval:   defaultInterval,
+		logger:     log.New("gc"),
+	}
+}
+
+func (r *Runner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.collect(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *Runner) collect(ctx context.Context) {
+	err := r.lock.LockAndExecute(ctx, "gc: garbage collect", r.interval, func(ctx context.Context) {
+		now := time.Now()
+		for _, collector := range r.collectors {
+			result, err := collector.GarbageCollect(ctx, now)
+			if err != nil {
+				r.logger.Error("Garbage collection failed", "error", err)
+				continue
+			}
+			for kind, deleted := range result {
+				r.logger.Debug("Garbage collected expired rows", "kind", kind, "rows affected", deleted)
+			}
+		}
+	})
+
+	if err != nil {
+		r.logger.Error("Failed to lock and execute garbage collection", "error", err)
+	}
+}
@@ -0,0 +1,161 @@
+package loginattempt
+
+import (
+	"context"
+	"time"
+)
+
+type Service interface {
+	Add(ctx context.Context, username, IPAddress string) error
+	Validate(ctx context.Context, username string) (ValidateResult, error)
+	ValidateIPAddress(ctx context.Context, IPAddress string) (ValidateResult, error)
+	// ValidatePair enforces escalating lockouts keyed on the (username, IP)
+	// pair. Callers that want EscalationScopePair should use it in place of
+	// separate Validate/ValidateIPAddress calls, since only a combined call
+	// has both halves of that key.
+	ValidatePair(ctx context.Context, username, IPAddress string) (ValidateResult, error)
+}
+
+// EscalationScope controls which identity a repeated lockout escalates
+// against: the username, the source IP, or the pair of both together.
+type EscalationScope int
+
+const (
+	EscalationScopeUser EscalationScope = iota
+	EscalationScopeIP
+	EscalationScopePair
+)
+
+// LockoutReason explains why a ValidateResult denied (or, for ReasonDisabled,
+// trivially allowed) a login.
+type LockoutReason int
+
+const (
+	ReasonNone LockoutReason = iota
+	// ReasonUserLocked means the username has exceeded its attempt threshold.
+	ReasonUserLocked
+	// ReasonIPLocked means the source IP has exceeded its attempt threshold.
+	ReasonIPLocked
+	// ReasonDisabled means brute-force login protection is turned off, so the
+	// result is always allowed.
+	ReasonDisabled
+)
+
+func (r LockoutReason) String() string {
+	switch r {
+	case ReasonUserLocked:
+		return "user-locked"
+	case ReasonIPLocked:
+		return "ip-locked"
+	case ReasonDisabled:
+		return "disabled"
+	default:
+		return "none"
+	}
+}
+
+// ValidateResult is the outcome of a Validate or ValidateIPAddress call. It
+// carries enough detail for callers to respond with a Retry-After header and
+// to log why a login was blocked.
+type ValidateResult struct {
+	Allowed           bool
+	Reason            LockoutReason
+	AttemptsRemaining int
+	RetryAfter        time.Duration
+	// Tier is the escalation tier from login_attempt_lockout that a blocked
+	// result is locked at. Higher tiers mean a longer RetryAfter and flag
+	// repeat offenders for the audit log. It is 0 on allowed results, and on
+	// blocked results when escalation does not apply to the check's scope.
+	Tier int
+}
+
+// LoginAttemptCountResult is the result of counting still-valid login
+// attempts, plus the expiry of the oldest one so callers can derive when a
+// slot will next free up without recomputing the window themselves.
+type LoginAttemptCountResult struct {
+	Count        int64
+	OldestExpiry time.Time
+}
+
+type LoginAttempt struct {
+	Id        int64
+	Username  string
+	IpAddress string
+	Created   int64
+	// Expiry is when this attempt stops counting towards any lockout
+	// threshold, stored as created + the window that was configured at
+	// creation time. Comparing against Expiry (rather than recomputing
+	// "Created >= now - window" on every read) means changing the
+	// configured window only affects attempts recorded after the change.
+	Expiry int64
+}
+
+type CreateLoginAttemptCommand struct {
+	Username  string
+	IpAddress string
+	// Expiry is the timestamp after which this attempt no longer counts
+	// towards a lockout threshold. Callers pass Created + the window that
+	// applies right now.
+	Expiry time.Time
+}
+
+type GetUserLoginAttemptCountQuery struct {
+	Username string
+	Now      time.Time
+}
+
+type GetIPLoginAttemptCountQuery struct {
+	IpAddress string
+	Now       time.Time
+}
+
+// GetPairLoginAttemptCountQuery counts attempts against the (username, IP)
+// pair specifically, as opposed to GetUserLoginAttemptCountQuery's
+// username-wide count. EscalationScopePair escalation must gate on this, not
+// on the username-wide count, or one bad IP ends up locking out every IP a
+// legitimate user logs in from.
+type GetPairLoginAttemptCountQuery struct {
+	Username  string
+	IpAddress string
+	Now       time.Time
+}
+
+// LoginAttemptLockout records one escalation event: the moment username
+// and/or IpAddress (depending on Scope) crossed the raw attempt threshold,
+// the tier that crossing landed on, and when that tier's lockout expires.
+type LoginAttemptLockout struct {
+	Id        int64
+	Username  string
+	IpAddress string
+	Tier      int
+	Created   int64
+	Expiry    int64
+}
+
+type GetLoginAttemptLockoutQuery struct {
+	Scope     EscalationScope
+	Username  string
+	IpAddress string
+	// Since bounds how far back the most recent lockout event is looked up,
+	// i.e. the escalation horizon (e.g. 24h) rather than the tier's own
+	// expiry, so a tier can be found and escalated from even after it has
+	// stopped actively blocking logins.
+	Since time.Time
+}
+
+type RecordLoginAttemptLockoutCommand struct {
+	Scope     EscalationScope
+	Username  string
+	IpAddress string
+	Tier      int
+	Expiry    time.Time
+}
+
+// LoginAttemptLockoutResult is the most recent lockout event for a scope
+// key inside the escalation horizon, if any.
+type LoginAttemptLockoutResult struct {
+	// Locked is true while Expiry is still in the future.
+	Locked bool
+	Tier   int
+	Expiry time.Time
+}
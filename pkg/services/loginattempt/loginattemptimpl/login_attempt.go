@@ -7,14 +7,31 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/gc"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+// Defaults used when the operator hasn't set the corresponding setting.Cfg
+// field (e.g. upgrading from a config file that predates these settings).
 const (
-	maxInvalidLoginAttempts int64 = 5
-	loginAttemptsWindow           = time.Minute * 5
+	defaultMaxInvalidLoginAttempts      int64 = 5
+	defaultMaxInvalidLoginAttemptsPerIP int64 = 10
+	defaultLoginAttemptsWindow                = time.Minute * 5
+	// defaultLockoutHistoryWindow is how far back Validate looks for a prior
+	// lockout event when deciding the next escalation tier.
+	defaultLockoutHistoryWindow = time.Hour * 24
 )
 
+// defaultLockoutLadder is how long each successive lockout tier lasts,
+// capped at its last entry for any further escalation within the history
+// window.
+var defaultLockoutLadder = []time.Duration{
+	time.Minute * 5,
+	time.Minute * 15,
+	time.Hour,
+}
+
 func ProvideService(db db.DB, cfg *setting.Cfg, lock *serverlock.ServerLockService) *Service {
 	return &Service{
 		&xormStore{db: db, now: time.Now},
@@ -31,21 +48,125 @@ type Service struct {
 	logger log.Logger
 }
 
-func (s *Service) Run(ctx context.Context) error {
-	// no need to run clean up job if it is disabled
+var (
+	_ loginattempt.Service = (*Service)(nil)
+	_ gc.GarbageCollector  = (*Service)(nil)
+)
+
+// loginAttemptsWindow returns the configured brute-force tracking window, or
+// its built-in default if the operator hasn't set one.
+func (s *Service) loginAttemptsWindow() time.Duration {
+	if s.cfg.BruteForceLoginProtectionWindow > 0 {
+		return s.cfg.BruteForceLoginProtectionWindow
+	}
+	return defaultLoginAttemptsWindow
+}
+
+// maxInvalidLoginAttempts returns the configured per-username attempt
+// threshold, or its built-in default if the operator hasn't set one.
+func (s *Service) maxInvalidLoginAttempts() int64 {
+	if s.cfg.BruteForceLoginProtectionMaxAttempts > 0 {
+		return s.cfg.BruteForceLoginProtectionMaxAttempts
+	}
+	return defaultMaxInvalidLoginAttempts
+}
+
+// maxInvalidLoginAttemptsPerIP returns the configured per-IP attempt
+// threshold, or its built-in default if the operator hasn't set one.
+func (s *Service) maxInvalidLoginAttemptsPerIP() int64 {
+	if s.cfg.BruteForceLoginProtectionMaxAttemptsPerIP > 0 {
+		return s.cfg.BruteForceLoginProtectionMaxAttemptsPerIP
+	}
+	return defaultMaxInvalidLoginAttemptsPerIP
+}
+
+// lockoutHistoryWindow returns the configured escalation horizon, or its
+// built-in default if the operator hasn't set one.
+func (s *Service) lockoutHistoryWindow() time.Duration {
+	if s.cfg.BruteForceLockoutHistoryWindow > 0 {
+		return s.cfg.BruteForceLockoutHistoryWindow
+	}
+	return defaultLockoutHistoryWindow
+}
+
+// lockoutLadder returns the configured per-tier lockout durations, or the
+// built-in default if the operator hasn't set any.
+func (s *Service) lockoutLadder() []time.Duration {
+	if len(s.cfg.BruteForceLockoutLadder) > 0 {
+		return s.cfg.BruteForceLockoutLadder
+	}
+	return defaultLockoutLadder
+}
+
+// lockoutScope returns which identity escalating lockouts are tracked
+// against.
+func (s *Service) lockoutScope() loginattempt.EscalationScope {
+	switch s.cfg.BruteForceLockoutScope {
+	case "ip":
+		return loginattempt.EscalationScopeIP
+	case "pair":
+		return loginattempt.EscalationScopePair
+	default:
+		return loginattempt.EscalationScopeUser
+	}
+}
+
+// escalate advances a scope key to its next lockout tier (capped at the
+// ladder's last entry) and records the event, returning the tier and expiry
+// that now apply.
+func (s *Service) escalate(ctx context.Context, scope loginattempt.EscalationScope, username, ipAddress string, prevTier int) (loginattempt.LoginAttemptLockoutResult, error) {
+	ladder := s.lockoutLadder()
+
+	tier := prevTier + 1
+	if tier > len(ladder) {
+		tier = len(ladder)
+	}
+	expiry := time.Now().Add(ladder[tier-1])
+
+	err := s.store.RecordLoginAttemptLockout(ctx, loginattempt.RecordLoginAttemptLockoutCommand{
+		Scope:     scope,
+		Username:  username,
+		IpAddress: ipAddress,
+		Tier:      tier,
+		Expiry:    expiry,
+	})
+
+	return loginattempt.LoginAttemptLockoutResult{Locked: true, Tier: tier, Expiry: expiry}, err
+}
+
+// retryAfter reports how long until oldestExpiry, the expiry of the oldest
+// attempt still counting against a threshold. Deriving it straight from the
+// row's stored expiry (rather than the currently configured window) means it
+// decays as attempts age out and doesn't jump around if the window changes.
+func retryAfter(oldestExpiry time.Time) time.Duration {
+	if oldestExpiry.IsZero() {
+		return 0
+	}
+
+	remaining := time.Until(oldestExpiry)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// GarbageCollect implements gc.GarbageCollector. Expiring login attempts
+// used to run on their own 10-minute ticker in Run; that ticker now lives on
+// the shared gc.Runner, with Service registered as one of its collectors.
+func (s *Service) GarbageCollect(ctx context.Context, now time.Time) (gc.Result, error) {
 	if s.cfg.DisableBruteForceLoginProtection {
-		return nil
+		return nil, nil
 	}
 
-	ticker := time.NewTicker(time.Minute * 10)
-	for {
-		select {
-		case <-ticker.C:
-			s.cleanup(ctx)
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+	res, err := s.store.GarbageCollect(ctx, now, s.lockoutHistoryWindow())
+	if err != nil {
+		return nil, err
 	}
+
+	return gc.Result{
+		"login_attempt":         res.DeletedLoginAttempts,
+		"login_attempt_lockout": res.DeletedLockouts,
+	}, nil
 }
 
 func (s *Service) Add(ctx context.Context, username, IPAddress string) error {
@@ -53,47 +174,201 @@ func (s *Service) Add(ctx context.Context, username, IPAddress string) error {
 		return nil
 	}
 
-	return s.store.CreateLoginAttempt(ctx, CreateLoginAttemptCommand{
+	return s.store.CreateLoginAttempt(ctx, loginattempt.CreateLoginAttemptCommand{
 		Username:  username,
 		IpAddress: IPAddress,
+		Expiry:    time.Now().Add(s.loginAttemptsWindow()),
 	})
 }
 
-func (s *Service) Validate(ctx context.Context, username string) (bool, error) {
+// Validate reports whether username may attempt another login. It only
+// looks at the username index; callers that also have the request's IP
+// address should additionally call ValidateIPAddress so a spray across many
+// usernames from one IP is caught. If the configured EscalationScope tracks
+// usernames, it also consults login_attempt_lockout first and escalates to
+// the next tier when the raw threshold is crossed.
+func (s *Service) Validate(ctx context.Context, username string) (loginattempt.ValidateResult, error) {
 	if s.cfg.DisableBruteForceLoginProtection {
-		return true, nil
+		return loginattempt.ValidateResult{Allowed: true, Reason: loginattempt.ReasonDisabled}, nil
 	}
 
-	loginAttemptCountQuery := GetUserLoginAttemptCountQuery{
-		Username: username,
-		Since:    time.Now().Add(-loginAttemptsWindow),
+	tiered := s.lockoutScope() != loginattempt.EscalationScopeIP
+	var prevTier int
+
+	if tiered {
+		lockout, err := s.store.GetLoginAttemptLockout(ctx, loginattempt.GetLoginAttemptLockoutQuery{
+			Scope:    loginattempt.EscalationScopeUser,
+			Username: username,
+			Since:    time.Now().Add(-s.lockoutHistoryWindow()),
+		})
+		if err != nil {
+			return loginattempt.ValidateResult{}, err
+		}
+		if lockout.Locked {
+			return loginattempt.ValidateResult{
+				Reason:     loginattempt.ReasonUserLocked,
+				Tier:       lockout.Tier,
+				RetryAfter: retryAfter(lockout.Expiry),
+			}, nil
+		}
+		prevTier = lockout.Tier
 	}
 
-	count, err := s.store.GetUserLoginAttemptCount(ctx, loginAttemptCountQuery)
+	maxAttempts := s.maxInvalidLoginAttempts()
+
+	res, err := s.store.GetUserLoginAttemptCount(ctx, loginattempt.GetUserLoginAttemptCountQuery{
+		Username: username,
+		Now:      time.Now(),
+	})
 	if err != nil {
-		return false, err
+		return loginattempt.ValidateResult{}, err
 	}
 
-	if count >= maxInvalidLoginAttempts {
-		return false, nil
+	if res.Count >= maxAttempts {
+		result := loginattempt.ValidateResult{
+			Reason:     loginattempt.ReasonUserLocked,
+			RetryAfter: retryAfter(res.OldestExpiry),
+		}
+
+		if tiered {
+			lockout, err := s.escalate(ctx, loginattempt.EscalationScopeUser, username, "", prevTier)
+			if err != nil {
+				return loginattempt.ValidateResult{}, err
+			}
+			result.Tier = lockout.Tier
+			result.RetryAfter = retryAfter(lockout.Expiry)
+		}
+
+		return result, nil
 	}
 
-	return true, nil
+	return loginattempt.ValidateResult{
+		Allowed:           true,
+		AttemptsRemaining: int(maxAttempts - res.Count),
+	}, nil
 }
 
-func (s *Service) cleanup(ctx context.Context) {
-	err := s.lock.LockAndExecute(ctx, "delete old login attempts", time.Minute*10, func(context.Context) {
-		cmd := DeleteOldLoginAttemptsCommand{
-			OlderThan: time.Now().Add(time.Minute * -10),
+// ValidateIPAddress reports whether IPAddress may attempt another login,
+// regardless of which usernames were attempted from it. If the configured
+// EscalationScope tracks IPs, it also consults login_attempt_lockout first
+// and escalates to the next tier when the raw threshold is crossed.
+func (s *Service) ValidateIPAddress(ctx context.Context, IPAddress string) (loginattempt.ValidateResult, error) {
+	if s.cfg.DisableBruteForceLoginProtection {
+		return loginattempt.ValidateResult{Allowed: true, Reason: loginattempt.ReasonDisabled}, nil
+	}
+
+	tiered := s.lockoutScope() != loginattempt.EscalationScopeUser
+	var prevTier int
+
+	if tiered {
+		lockout, err := s.store.GetLoginAttemptLockout(ctx, loginattempt.GetLoginAttemptLockoutQuery{
+			Scope:     loginattempt.EscalationScopeIP,
+			IpAddress: IPAddress,
+			Since:     time.Now().Add(-s.lockoutHistoryWindow()),
+		})
+		if err != nil {
+			return loginattempt.ValidateResult{}, err
+		}
+		if lockout.Locked {
+			return loginattempt.ValidateResult{
+				Reason:     loginattempt.ReasonIPLocked,
+				Tier:       lockout.Tier,
+				RetryAfter: retryAfter(lockout.Expiry),
+			}, nil
+		}
+		prevTier = lockout.Tier
+	}
+
+	maxAttempts := s.maxInvalidLoginAttemptsPerIP()
+
+	res, err := s.store.GetIPLoginAttemptCount(ctx, loginattempt.GetIPLoginAttemptCountQuery{
+		IpAddress: IPAddress,
+		Now:       time.Now(),
+	})
+	if err != nil {
+		return loginattempt.ValidateResult{}, err
+	}
+
+	if res.Count >= maxAttempts {
+		result := loginattempt.ValidateResult{
+			Reason:     loginattempt.ReasonIPLocked,
+			RetryAfter: retryAfter(res.OldestExpiry),
 		}
-		if deletedLogs, err := s.store.DeleteOldLoginAttempts(ctx, cmd); err != nil {
-			s.logger.Error("Problem deleting expired login attempts", "error", err.Error())
-		} else {
-			s.logger.Debug("Deleted expired login attempts", "rows affected", deletedLogs)
+
+		if tiered {
+			lockout, err := s.escalate(ctx, loginattempt.EscalationScopeIP, "", IPAddress, prevTier)
+			if err != nil {
+				return loginattempt.ValidateResult{}, err
+			}
+			result.Tier = lockout.Tier
+			result.RetryAfter = retryAfter(lockout.Expiry)
 		}
+
+		return result, nil
+	}
+
+	return loginattempt.ValidateResult{
+		Allowed:           true,
+		AttemptsRemaining: int(maxAttempts - res.Count),
+	}, nil
+}
+
+// ValidatePair enforces an escalating lockout keyed on the (username, IP)
+// pair. It only applies tiering when BruteForceLockoutScope is "pair";
+// otherwise it defers to Validate, since that's the scope actually
+// configured to own escalation.
+func (s *Service) ValidatePair(ctx context.Context, username, IPAddress string) (loginattempt.ValidateResult, error) {
+	if s.cfg.DisableBruteForceLoginProtection {
+		return loginattempt.ValidateResult{Allowed: true, Reason: loginattempt.ReasonDisabled}, nil
+	}
+
+	if s.lockoutScope() != loginattempt.EscalationScopePair {
+		return s.Validate(ctx, username)
+	}
+
+	lockout, err := s.store.GetLoginAttemptLockout(ctx, loginattempt.GetLoginAttemptLockoutQuery{
+		Scope:     loginattempt.EscalationScopePair,
+		Username:  username,
+		IpAddress: IPAddress,
+		Since:     time.Now().Add(-s.lockoutHistoryWindow()),
 	})
+	if err != nil {
+		return loginattempt.ValidateResult{}, err
+	}
+	if lockout.Locked {
+		return loginattempt.ValidateResult{
+			Reason:     loginattempt.ReasonUserLocked,
+			Tier:       lockout.Tier,
+			RetryAfter: retryAfter(lockout.Expiry),
+		}, nil
+	}
+
+	maxAttempts := s.maxInvalidLoginAttempts()
 
+	res, err := s.store.GetPairLoginAttemptCount(ctx, loginattempt.GetPairLoginAttemptCountQuery{
+		Username:  username,
+		IpAddress: IPAddress,
+		Now:       time.Now(),
+	})
 	if err != nil {
-		s.logger.Error("failed to lock and execute cleanup of old login attempts", "error", err)
+		return loginattempt.ValidateResult{}, err
 	}
+
+	if res.Count >= maxAttempts {
+		next, err := s.escalate(ctx, loginattempt.EscalationScopePair, username, IPAddress, lockout.Tier)
+		if err != nil {
+			return loginattempt.ValidateResult{}, err
+		}
+
+		return loginattempt.ValidateResult{
+			Reason:     loginattempt.ReasonUserLocked,
+			Tier:       next.Tier,
+			RetryAfter: retryAfter(next.Expiry),
+		}, nil
+	}
+
+	return loginattempt.ValidateResult{
+		Allowed:           true,
+		AttemptsRemaining: int(maxAttempts - res.Count),
+	}, nil
 }
@@ -0,0 +1,129 @@
+package loginattemptimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeStore is an in-memory store used to exercise Service without a
+// database, keyed the same way xormStore's queries are (by username,
+// IP address, and the pair of both).
+type fakeStore struct {
+	userCounts   map[string]int64
+	ipCounts     map[string]int64
+	pairCounts   map[string]int64
+	oldestExpiry time.Time
+	lockouts     []loginattempt.LoginAttemptLockout
+	gcCutoffSeen time.Duration
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		userCounts: map[string]int64{},
+		ipCounts:   map[string]int64{},
+		pairCounts: map[string]int64{},
+	}
+}
+
+func (f *fakeStore) CreateLoginAttempt(ctx context.Context, cmd loginattempt.CreateLoginAttemptCommand) error {
+	return nil
+}
+
+func (f *fakeStore) GetUserLoginAttemptCount(ctx context.Context, query loginattempt.GetUserLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error) {
+	return loginattempt.LoginAttemptCountResult{Count: f.userCounts[query.Username], OldestExpiry: f.oldestExpiry}, nil
+}
+
+func (f *fakeStore) GetIPLoginAttemptCount(ctx context.Context, query loginattempt.GetIPLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error) {
+	return loginattempt.LoginAttemptCountResult{Count: f.ipCounts[query.IpAddress], OldestExpiry: f.oldestExpiry}, nil
+}
+
+func (f *fakeStore) GetPairLoginAttemptCount(ctx context.Context, query loginattempt.GetPairLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error) {
+	return loginattempt.LoginAttemptCountResult{Count: f.pairCounts[query.Username+"|"+query.IpAddress], OldestExpiry: f.oldestExpiry}, nil
+}
+
+func (f *fakeStore) GetLoginAttemptLockout(ctx context.Context, query loginattempt.GetLoginAttemptLockoutQuery) (loginattempt.LoginAttemptLockoutResult, error) {
+	return loginattempt.LoginAttemptLockoutResult{}, nil
+}
+
+func (f *fakeStore) RecordLoginAttemptLockout(ctx context.Context, cmd loginattempt.RecordLoginAttemptLockoutCommand) error {
+	f.lockouts = append(f.lockouts, loginattempt.LoginAttemptLockout{
+		Username:  cmd.Username,
+		IpAddress: cmd.IpAddress,
+		Tier:      cmd.Tier,
+		Expiry:    cmd.Expiry.Unix(),
+	})
+	return nil
+}
+
+func (f *fakeStore) GarbageCollect(ctx context.Context, now time.Time, lockoutHistoryWindow time.Duration) (GCResult, error) {
+	f.gcCutoffSeen = lockoutHistoryWindow
+	return GCResult{}, nil
+}
+
+func newTestService(fs *fakeStore) *Service {
+	return &Service{fs, &setting.Cfg{}, nil, nil}
+}
+
+func TestEscalate_AdvancesOneTierAtATime(t *testing.T) {
+	fs := newFakeStore()
+	s := newTestService(fs)
+
+	result, err := s.escalate(context.Background(), loginattempt.EscalationScopeUser, "alice", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Tier)
+
+	result, err = s.escalate(context.Background(), loginattempt.EscalationScopeUser, "alice", "", result.Tier)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Tier)
+}
+
+func TestEscalate_CapsAtTheLastLadderEntry(t *testing.T) {
+	fs := newFakeStore()
+	s := newTestService(fs)
+
+	prevTier := len(defaultLockoutLadder)
+	result, err := s.escalate(context.Background(), loginattempt.EscalationScopeUser, "alice", "", prevTier)
+	require.NoError(t, err)
+	require.Equal(t, len(defaultLockoutLadder), result.Tier)
+}
+
+func TestRetryAfter_DecaysTowardsZero(t *testing.T) {
+	require.Equal(t, time.Duration(0), retryAfter(time.Time{}))
+	require.Equal(t, time.Duration(0), retryAfter(time.Now().Add(-time.Minute)))
+
+	remaining := retryAfter(time.Now().Add(time.Minute))
+	require.Greater(t, remaining, time.Duration(0))
+	require.LessOrEqual(t, remaining, time.Minute)
+}
+
+func TestValidatePair_ChecksThePairCountNotTheUsernameCount(t *testing.T) {
+	fs := newFakeStore()
+	fs.userCounts["alice"] = 5 // a different IP already failed 5 times
+	fs.pairCounts["alice|127.0.0.2"] = 0
+
+	cfg := &setting.Cfg{BruteForceLockoutScope: "pair"}
+	s := &Service{fs, cfg, nil, nil}
+
+	result, err := s.ValidatePair(context.Background(), "alice", "127.0.0.2")
+	require.NoError(t, err)
+	require.True(t, result.Allowed, "a pair with no failed attempts of its own must not be blocked by another IP's failures")
+}
+
+func TestValidatePair_BlocksOnceThePairItselfCrossesTheThreshold(t *testing.T) {
+	fs := newFakeStore()
+	fs.pairCounts["alice|127.0.0.2"] = defaultMaxInvalidLoginAttempts
+
+	cfg := &setting.Cfg{BruteForceLockoutScope: "pair"}
+	s := &Service{fs, cfg, nil, nil}
+
+	result, err := s.ValidatePair(context.Background(), "alice", "127.0.0.2")
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, loginattempt.ReasonUserLocked, result.Reason)
+}
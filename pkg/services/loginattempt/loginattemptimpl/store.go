@@ -0,0 +1,211 @@
+package loginattemptimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+)
+
+type store interface {
+	CreateLoginAttempt(ctx context.Context, cmd loginattempt.CreateLoginAttemptCommand) error
+	GetUserLoginAttemptCount(ctx context.Context, query loginattempt.GetUserLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error)
+	GetIPLoginAttemptCount(ctx context.Context, query loginattempt.GetIPLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error)
+	GetPairLoginAttemptCount(ctx context.Context, query loginattempt.GetPairLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error)
+	GetLoginAttemptLockout(ctx context.Context, query loginattempt.GetLoginAttemptLockoutQuery) (loginattempt.LoginAttemptLockoutResult, error)
+	RecordLoginAttemptLockout(ctx context.Context, cmd loginattempt.RecordLoginAttemptLockoutCommand) error
+	// GarbageCollect deletes expired login attempts and lockout events.
+	// lockoutHistoryWindow is the operator-configured escalation horizon
+	// (Service.lockoutHistoryWindow()) used as the lockout retention cutoff,
+	// so GC never purges history the ladder is still relying on.
+	GarbageCollect(ctx context.Context, now time.Time, lockoutHistoryWindow time.Duration) (GCResult, error)
+}
+
+// GCResult reports how many rows were removed by a GarbageCollect pass.
+// Other expired artifacts (session tokens, auth tokens, ...) can add fields
+// here as they move onto the shared gc.Runner.
+type GCResult struct {
+	DeletedLoginAttempts int64
+	DeletedLockouts      int64
+}
+
+type xormStore struct {
+	db  db.DB
+	now func() time.Time
+}
+
+func (s *xormStore) CreateLoginAttempt(ctx context.Context, cmd loginattempt.CreateLoginAttemptCommand) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		loginAttempt := loginattempt.LoginAttempt{
+			Username:  cmd.Username,
+			IpAddress: cmd.IpAddress,
+			Created:   s.now().Unix(),
+			Expiry:    cmd.Expiry.Unix(),
+		}
+
+		_, err := sess.Insert(&loginAttempt)
+		return err
+	})
+}
+
+func (s *xormStore) GetUserLoginAttemptCount(ctx context.Context, query loginattempt.GetUserLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error) {
+	var result loginattempt.LoginAttemptCountResult
+
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		count, err := sess.Where("username = ? AND expiry > ?", query.Username, query.Now.Unix()).Count(&loginattempt.LoginAttempt{})
+		if err != nil {
+			return err
+		}
+		result.Count = count
+
+		if count == 0 {
+			return nil
+		}
+
+		var oldest loginattempt.LoginAttempt
+		has, err := sess.Where("username = ? AND expiry > ?", query.Username, query.Now.Unix()).OrderBy("expiry asc").Get(&oldest)
+		if err != nil {
+			return err
+		}
+		if has {
+			result.OldestExpiry = time.Unix(oldest.Expiry, 0)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *xormStore) GetIPLoginAttemptCount(ctx context.Context, query loginattempt.GetIPLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error) {
+	var result loginattempt.LoginAttemptCountResult
+
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		count, err := sess.Where("ip_address = ? AND expiry > ?", query.IpAddress, query.Now.Unix()).Count(&loginattempt.LoginAttempt{})
+		if err != nil {
+			return err
+		}
+		result.Count = count
+
+		if count == 0 {
+			return nil
+		}
+
+		var oldest loginattempt.LoginAttempt
+		has, err := sess.Where("ip_address = ? AND expiry > ?", query.IpAddress, query.Now.Unix()).OrderBy("expiry asc").Get(&oldest)
+		if err != nil {
+			return err
+		}
+		if has {
+			result.OldestExpiry = time.Unix(oldest.Expiry, 0)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *xormStore) GetPairLoginAttemptCount(ctx context.Context, query loginattempt.GetPairLoginAttemptCountQuery) (loginattempt.LoginAttemptCountResult, error) {
+	var result loginattempt.LoginAttemptCountResult
+
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		count, err := sess.Where("username = ? AND ip_address = ? AND expiry > ?", query.Username, query.IpAddress, query.Now.Unix()).Count(&loginattempt.LoginAttempt{})
+		if err != nil {
+			return err
+		}
+		result.Count = count
+
+		if count == 0 {
+			return nil
+		}
+
+		var oldest loginattempt.LoginAttempt
+		has, err := sess.Where("username = ? AND ip_address = ? AND expiry > ?", query.Username, query.IpAddress, query.Now.Unix()).OrderBy("expiry asc").Get(&oldest)
+		if err != nil {
+			return err
+		}
+		if has {
+			result.OldestExpiry = time.Unix(oldest.Expiry, 0)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// lockoutScopeCondition builds the WHERE clause that identifies
+// login_attempt_lockout rows for a given scope key.
+func lockoutScopeCondition(scope loginattempt.EscalationScope, username, ipAddress string) (string, []interface{}) {
+	switch scope {
+	case loginattempt.EscalationScopeIP:
+		return "ip_address = ? AND username = ?", []interface{}{ipAddress, ""}
+	case loginattempt.EscalationScopePair:
+		return "username = ? AND ip_address = ?", []interface{}{username, ipAddress}
+	default:
+		return "username = ? AND ip_address = ?", []interface{}{username, ""}
+	}
+}
+
+func (s *xormStore) GetLoginAttemptLockout(ctx context.Context, query loginattempt.GetLoginAttemptLockoutQuery) (loginattempt.LoginAttemptLockoutResult, error) {
+	var result loginattempt.LoginAttemptLockoutResult
+
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		cond, args := lockoutScopeCondition(query.Scope, query.Username, query.IpAddress)
+
+		var row loginattempt.LoginAttemptLockout
+		has, err := sess.Where(cond+" AND created >= ?", append(args, query.Since.Unix())...).
+			OrderBy("created desc").Get(&row)
+		if err != nil || !has {
+			return err
+		}
+
+		result.Tier = row.Tier
+		result.Expiry = time.Unix(row.Expiry, 0)
+		result.Locked = result.Expiry.After(s.now())
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *xormStore) RecordLoginAttemptLockout(ctx context.Context, cmd loginattempt.RecordLoginAttemptLockoutCommand) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		lockout := loginattempt.LoginAttemptLockout{
+			Username:  cmd.Username,
+			IpAddress: cmd.IpAddress,
+			Tier:      cmd.Tier,
+			Created:   s.now().Unix(),
+			Expiry:    cmd.Expiry.Unix(),
+		}
+
+		_, err := sess.Insert(&lockout)
+		return err
+	})
+}
+
+func (s *xormStore) GarbageCollect(ctx context.Context, now time.Time, lockoutHistoryWindow time.Duration) (GCResult, error) {
+	var result GCResult
+
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		reply, err := sess.Exec("DELETE FROM login_attempt WHERE expiry < ?", now.Unix())
+		if err != nil {
+			return err
+		}
+		if result.DeletedLoginAttempts, err = reply.RowsAffected(); err != nil {
+			return err
+		}
+
+		reply, err = sess.Exec("DELETE FROM login_attempt_lockout WHERE created < ?", now.Add(-lockoutHistoryWindow).Unix())
+		if err != nil {
+			return err
+		}
+		result.DeletedLockouts, err = reply.RowsAffected()
+		return err
+	})
+
+	return result, err
+}
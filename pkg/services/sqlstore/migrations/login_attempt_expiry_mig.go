@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// addLoginAttemptExpiryMigrations adds the expiry column used to decide
+// whether a login_attempt row still counts towards a lockout threshold,
+// backfilling existing rows with the window that was in effect before this
+// column existed. OSSMigrations.AddMigration registers it.
+func addLoginAttemptExpiryMigrations(mg *migrator.Migrator) {
+	mg.AddMigration("add expiry column to login_attempt", migrator.NewAddColumnMigration(
+		migrator.Table{Name: "login_attempt"},
+		&migrator.Column{Name: "expiry", Type: migrator.DB_BigInt, Nullable: true},
+	))
+
+	mg.AddMigration("backfill login_attempt.expiry from created", migrator.NewRawSQLMigration(
+		"UPDATE login_attempt SET expiry = created + 300 WHERE expiry IS NULL",
+	))
+
+	mg.AddMigration("add index login_attempt.expiry", migrator.NewAddIndexMigration(
+		migrator.Table{Name: "login_attempt"},
+		&migrator.Index{Cols: []string{"expiry"}},
+	))
+}
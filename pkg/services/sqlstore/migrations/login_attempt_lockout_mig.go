@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// addLoginAttemptLockoutMigrations creates the table backing the
+// exponential-backoff lockout tier on top of loginattemptimpl's flat
+// attempt threshold: one row per escalation event, keyed on whichever
+// identity (username, IP, or both) the configured EscalationScope tracks.
+func addLoginAttemptLockoutMigrations(mg *migrator.Migrator) {
+	loginAttemptLockoutV1 := migrator.Table{
+		Name: "login_attempt_lockout",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "username", Type: migrator.DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "ip_address", Type: migrator.DB_NVarchar, Length: 45, Nullable: true},
+			{Name: "tier", Type: migrator.DB_Int, Nullable: false},
+			{Name: "created", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "expiry", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"username", "created"}},
+			{Cols: []string{"ip_address", "created"}},
+		},
+	}
+
+	mg.AddMigration("create login_attempt_lockout table", migrator.NewAddTableMigration(loginAttemptLockoutV1))
+
+	mg.AddMigration("add index login_attempt_lockout.username_created", migrator.NewAddIndexMigration(loginAttemptLockoutV1, loginAttemptLockoutV1.Indices[0]))
+	mg.AddMigration("add index login_attempt_lockout.ip_address_created", migrator.NewAddIndexMigration(loginAttemptLockoutV1, loginAttemptLockoutV1.Indices[1]))
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// OSSMigrations registers every migration the open-source build ships. Each
+// add*Migrations function owns one logical group of schema changes; this
+// snapshot only carries the login_attempt ones.
+type OSSMigrations struct{}
+
+func ProvideOSSMigrations() *OSSMigrations {
+	return &OSSMigrations{}
+}
+
+func (*OSSMigrations) AddMigration(mg *migrator.Migrator) {
+	addLoginAttemptExpiryMigrations(mg)
+	addLoginAttemptLockoutMigrations(mg)
+}
@@ -0,0 +1,51 @@
+package setting
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// readBruteForceLoginProtectionSettings parses the [security] brute-force
+// options into cfg, so operators can tune loginattemptimpl.Service without a
+// rebuild. Zero-value fields fall back to that package's own defaults.
+func (cfg *Cfg) readBruteForceLoginProtectionSettings(iniFile *ini.File) error {
+	sec := iniFile.Section("security")
+
+	cfg.DisableBruteForceLoginProtection = sec.Key("disable_brute_force_login_protection").MustBool(false)
+	cfg.BruteForceLoginProtectionWindow = sec.Key("brute_force_login_protection_window").MustDuration(0)
+	cfg.BruteForceLoginProtectionMaxAttempts = sec.Key("brute_force_login_protection_max_attempts").MustInt64(0)
+	cfg.BruteForceLoginProtectionMaxAttemptsPerIP = sec.Key("brute_force_login_protection_max_attempts_per_ip").MustInt64(0)
+	cfg.BruteForceLockoutHistoryWindow = sec.Key("brute_force_lockout_history_window").MustDuration(0)
+	cfg.BruteForceLockoutScope = sec.Key("brute_force_lockout_scope").MustString("user")
+
+	ladder, err := parseDurationList(sec.Key("brute_force_lockout_ladder").String())
+	if err != nil {
+		return err
+	}
+	cfg.BruteForceLockoutLadder = ladder
+
+	return nil
+}
+
+// parseDurationList parses a comma-separated list of durations, e.g.
+// "5m,15m,1h", as used by the brute_force_lockout_ladder setting. An empty
+// string returns a nil slice so the caller's own default takes over.
+func parseDurationList(raw string) ([]time.Duration, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	durations := make([]time.Duration, 0, len(parts))
+	for _, p := range parts {
+		d, err := time.ParseDuration(strings.TrimSpace(p))
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, d)
+	}
+
+	return durations, nil
+}
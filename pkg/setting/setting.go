@@ -0,0 +1,34 @@
+package setting
+
+import (
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// Cfg holds Grafana's parsed configuration. This snapshot only carries the
+// fields loginattemptimpl depends on; the full build has many more,
+// populated the same way via their own read*Settings methods.
+type Cfg struct {
+	Raw *ini.File
+
+	DisableBruteForceLoginProtection          bool
+	BruteForceLoginProtectionWindow           time.Duration
+	BruteForceLoginProtectionMaxAttempts      int64
+	BruteForceLoginProtectionMaxAttemptsPerIP int64
+	BruteForceLockoutHistoryWindow            time.Duration
+	BruteForceLockoutLadder                   []time.Duration
+	BruteForceLockoutScope                    string
+}
+
+// NewCfgFromINIFile builds a Cfg from an already-loaded ini.File, the same
+// source Cfg.Load parses from in the full build.
+func NewCfgFromINIFile(iniFile *ini.File) (*Cfg, error) {
+	cfg := &Cfg{Raw: iniFile}
+
+	if err := cfg.readBruteForceLoginProtectionSettings(iniFile); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}